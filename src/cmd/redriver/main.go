@@ -0,0 +1,104 @@
+// Command redriver replays messages from a dead-letter queue back to
+// their source queue (or another destination), and can report DLQ
+// contents grouped by failure reason without consuming anything.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/cs6650/final-mastery/processor/redrive"
+)
+
+func main() {
+	dlqURL := flag.String("dlq-url", os.Getenv("DEAD_LETTER_QUEUE_URL"), "DLQ URL to redrive from")
+	targetURL := flag.String("target-url", os.Getenv("SQS_QUEUE_URL"), "destination SQS queue URL to redrive to (SNS topics are not supported)")
+	maxMessages := flag.Int("max-messages", 0, "stop after inspecting this many DLQ messages (0 = unlimited)")
+	sample := flag.Int("sample", 0, "stop after successfully replaying this many messages (0 = replay every match)")
+	rate := flag.Float64("rate", 0, "maximum messages/sec to redrive (0 = unlimited)")
+	filter := flag.String("filter", "", "jq-style expression evaluated against each message body; only matches are redriven")
+	dryRun := flag.Bool("dry-run", false, "report what would be redriven without sending or deleting anything")
+	stats := flag.Bool("stats", false, "inspect DLQ contents grouped by failure reason, without consuming messages")
+	flag.Parse()
+
+	if *dlqURL == "" {
+		log.Fatal("--dlq-url (or DEAD_LETTER_QUEUE_URL) must be set")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		cancel()
+	}()
+
+	client, err := newSQSClient(ctx)
+	if err != nil {
+		log.Fatalf("failed to initialize SQS client: %v", err)
+	}
+
+	if *stats {
+		res, err := redrive.Stats(ctx, client, *dlqURL, *maxMessages)
+		if err != nil {
+			log.Fatalf("stats failed: %v", err)
+		}
+		fmt.Printf("total=%d\n", res.Total)
+		for reason, count := range res.ByReason {
+			fmt.Printf("  %-30s %d\n", reason, count)
+		}
+		return
+	}
+
+	if *targetURL == "" {
+		log.Fatal("--target-url (or SQS_QUEUE_URL) must be set")
+	}
+
+	replayer, err := redrive.New(client, *dlqURL, *targetURL, redrive.Options{
+		MaxMessages: *maxMessages,
+		Sample:      *sample,
+		RatePerSec:  *rate,
+		Filter:      *filter,
+		DryRun:      *dryRun,
+	})
+	if err != nil {
+		log.Fatalf("failed to initialize redriver: %v", err)
+	}
+
+	res, err := replayer.Run(ctx)
+	if err != nil {
+		log.Fatalf("redrive failed: %v", err)
+	}
+	log.Printf("redrive complete: inspected=%d matched=%d replayed=%d skipped=%d errors=%d dry_run=%t",
+		res.Inspected, res.Matched, res.Replayed, res.Skipped, res.Errors, *dryRun)
+}
+
+// newSQSClient mirrors the processor's AWS config loading, including the
+// optional custom endpoint used against localstack.
+func newSQSClient(ctx context.Context) (*sqs.Client, error) {
+	awsEndpoint := os.Getenv("AWS_ENDPOINT")
+	var cfg aws.Config
+	var err error
+	if awsEndpoint != "" {
+		resolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+			return aws.Endpoint{URL: awsEndpoint, SigningRegion: os.Getenv("AWS_REGION")}, nil
+		})
+		cfg, err = config.LoadDefaultConfig(ctx, config.WithEndpointResolverWithOptions(resolver))
+	} else {
+		cfg, err = config.LoadDefaultConfig(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sqs.NewFromConfig(cfg), nil
+}
@@ -0,0 +1,169 @@
+package autoscale
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Config tunes the adaptive concurrency controller.
+type Config struct {
+	MinConcurrency int64 // floor; defaults to 1
+	MaxConcurrency int64 // ceiling; required (matches the Semaphore's max)
+	PollInterval   time.Duration
+
+	// OnSample, if set, is called after every poll with the sampled
+	// queue state and the capacity the controller settled on, so
+	// callers can surface it as a metric.
+	OnSample func(depth, notVisible int64, avgLatency time.Duration, capacity int64)
+}
+
+func (c Config) withDefaults() Config {
+	if c.MinConcurrency < 1 {
+		c.MinConcurrency = 1
+	}
+	if c.MaxConcurrency < c.MinConcurrency {
+		c.MaxConcurrency = c.MinConcurrency
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = 10 * time.Second
+	}
+	return c
+}
+
+// Controller runs an additive-increase, multiplicative-decrease loop
+// against a Semaphore's capacity, driven by SQS queue depth
+// (ApproximateNumberOfMessages / ApproximateNumberOfMessagesNotVisible)
+// and the processor's observed processing latency: grow by one when the
+// queue is building up and latency is stable, halve on a receive error
+// or when latency roughly doubles.
+type Controller struct {
+	client   *sqs.Client
+	queueURL string
+	sem      *Semaphore
+	cfg      Config
+	latency  *latencyWindow
+	recvErrs atomic.Int64
+
+	lastDepth    int64
+	lastLatency  time.Duration
+	lastRecvErrs int64
+}
+
+// NewController constructs a Controller that resizes sem.
+func NewController(client *sqs.Client, queueURL string, sem *Semaphore, cfg Config) *Controller {
+	return &Controller{
+		client:   client,
+		queueURL: queueURL,
+		sem:      sem,
+		cfg:      cfg.withDefaults(),
+		latency:  newLatencyWindow(32),
+	}
+}
+
+// RecordLatency feeds one observed processing latency into the
+// controller's rolling average. Callers should invoke this once per
+// completed message, regardless of success or failure.
+func (c *Controller) RecordLatency(d time.Duration) {
+	c.latency.add(d)
+}
+
+// RecordReceiveError notes that the processor's Consumer failed to
+// receive from SQS (wire this up as consumer.Config.OnReceiveError). A
+// receive error observed since the last tick halves capacity just like a
+// queueDepth polling failure does.
+func (c *Controller) RecordReceiveError() {
+	c.recvErrs.Add(1)
+}
+
+// Run polls queue depth and latency every Config.PollInterval, adjusting
+// the semaphore's capacity with the AIMD policy, until ctx is done.
+func (c *Controller) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.tick(ctx)
+		}
+	}
+}
+
+func (c *Controller) tick(ctx context.Context) {
+	recvErrs := c.recvErrs.Load()
+	sawReceiveErrors := recvErrs > c.lastRecvErrs
+	c.lastRecvErrs = recvErrs
+
+	depth, notVisible, err := c.queueDepth(ctx)
+	if err != nil {
+		log.Printf("autoscale: failed to read queue attributes, backing off: %v", err)
+		c.sem.SetCapacity(max64(c.cfg.MinConcurrency, c.sem.Capacity()/2))
+		return
+	}
+
+	avgLatency := c.latency.average()
+	growing := depth > c.lastDepth
+	latencyStable := c.lastLatency == 0 || avgLatency <= c.lastLatency*2
+
+	switch {
+	case sawReceiveErrors:
+		log.Printf("autoscale: consumer reported receive errors, backing off")
+		c.sem.SetCapacity(max64(c.cfg.MinConcurrency, c.sem.Capacity()/2))
+	case !latencyStable:
+		c.sem.SetCapacity(max64(c.cfg.MinConcurrency, c.sem.Capacity()/2))
+	case growing:
+		c.sem.SetCapacity(min64(c.cfg.MaxConcurrency, c.sem.Capacity()+1))
+	}
+
+	if c.cfg.OnSample != nil {
+		c.cfg.OnSample(depth, notVisible, avgLatency, c.sem.Capacity())
+	}
+
+	c.lastDepth = depth
+	c.lastLatency = avgLatency
+}
+
+func (c *Controller) queueDepth(ctx context.Context) (depth, notVisible int64, err error) {
+	out, err := c.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl: &c.queueURL,
+		AttributeNames: []types.QueueAttributeName{
+			types.QueueAttributeNameApproximateNumberOfMessages,
+			types.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+		},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	depth = parseInt64(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)])
+	notVisible = parseInt64(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessagesNotVisible)])
+	return depth, notVisible, nil
+}
+
+func parseInt64(s string) int64 {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,46 @@
+package autoscale
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyWindow tracks a fixed-size rolling average of observed
+// processing latencies.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	filled  bool
+}
+
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, size)}
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % len(w.samples)
+	if w.next == 0 {
+		w.filled = true
+	}
+}
+
+func (w *latencyWindow) average() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := w.next
+	if w.filled {
+		n = len(w.samples)
+	}
+	if n == 0 {
+		return 0
+	}
+	var total time.Duration
+	for i := 0; i < n; i++ {
+		total += w.samples[i]
+	}
+	return total / time.Duration(n)
+}
@@ -0,0 +1,56 @@
+package autoscale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyWindowAverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		samples []time.Duration
+		want    time.Duration
+	}{
+		{
+			name: "empty window",
+			size: 4,
+			want: 0,
+		},
+		{
+			name:    "partially filled",
+			size:    4,
+			samples: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+			want:    15 * time.Millisecond,
+		},
+		{
+			name:    "exactly full",
+			size:    3,
+			samples: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond},
+			want:    20 * time.Millisecond,
+		},
+		{
+			name: "wraps and evicts the oldest samples",
+			size: 2,
+			samples: []time.Duration{
+				100 * time.Millisecond, // evicted
+				100 * time.Millisecond, // evicted
+				10 * time.Millisecond,
+				20 * time.Millisecond,
+			},
+			want: 15 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newLatencyWindow(tt.size)
+			for _, s := range tt.samples {
+				w.add(s)
+			}
+			if got := w.average(); got != tt.want {
+				t.Errorf("average() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
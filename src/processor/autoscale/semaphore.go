@@ -0,0 +1,93 @@
+// Package autoscale adapts a consumer's concurrency limit to observed
+// SQS queue depth and processing latency, replacing a fixed
+// PROCESSOR_CONCURRENCY value with an AIMD controller that grows when
+// there's a backlog building and backs off when latency regresses.
+package autoscale
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Semaphore is a counting semaphore whose capacity can be resized while
+// in use. inUse and capacity are both guarded by mu so a caller's
+// check-against-capacity and its acquisition of a slot happen as one
+// atomic step; checking length and sending on a channel as two separate
+// operations would let concurrent Acquire calls all observe "under
+// capacity" and all succeed, oversubscribing past a just-shrunk cap.
+type Semaphore struct {
+	mu       sync.Mutex
+	inUse    int64
+	capacity int64
+	max      int64
+}
+
+// NewSemaphore creates a Semaphore that starts at initial capacity and
+// can grow up to maxCapacity.
+func NewSemaphore(initial, maxCapacity int64) *Semaphore {
+	if maxCapacity < 1 {
+		maxCapacity = 1
+	}
+	if initial < 1 {
+		initial = 1
+	}
+	if initial > maxCapacity {
+		initial = maxCapacity
+	}
+	return &Semaphore{capacity: initial, max: maxCapacity}
+}
+
+// Acquire blocks until a slot is available under the current capacity,
+// or ctx is done.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		if s.inUse < s.capacity {
+			s.inUse++
+			s.mu.Unlock()
+			return nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+// Release frees the slot acquired by a prior successful Acquire.
+func (s *Semaphore) Release() {
+	s.mu.Lock()
+	s.inUse--
+	s.mu.Unlock()
+}
+
+// SetCapacity resizes the semaphore, clamped to [1, maxCapacity].
+func (s *Semaphore) SetCapacity(n int64) {
+	if n < 1 {
+		n = 1
+	}
+	if n > s.max {
+		n = s.max
+	}
+	s.mu.Lock()
+	s.capacity = n
+	s.mu.Unlock()
+}
+
+// Capacity reports the current capacity.
+func (s *Semaphore) Capacity() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.capacity
+}
+
+// InUse reports how many slots are currently held.
+func (s *Semaphore) InUse() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inUse
+}
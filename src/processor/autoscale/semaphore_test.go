@@ -0,0 +1,91 @@
+package autoscale
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// TestSemaphoreAcquireRespectsCapacity guards against the check-then-send
+// race: many goroutines racing Acquire against a low capacity must never
+// hold more concurrently-acquired slots than that capacity allows.
+func TestSemaphoreAcquireRespectsCapacity(t *testing.T) {
+	const (
+		capacity    = 1
+		contenders  = 20
+		maxObserved = capacity
+	)
+
+	s := NewSemaphore(capacity, 10)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	inUse, peak := 0, 0
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+
+	for i := 0; i < contenders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if err := s.Acquire(ctx); err != nil {
+				t.Errorf("Acquire: %v", err)
+				return
+			}
+			mu.Lock()
+			inUse++
+			if inUse > peak {
+				peak = inUse
+			}
+			mu.Unlock()
+
+			mu.Lock()
+			inUse--
+			mu.Unlock()
+			s.Release()
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	if peak > maxObserved {
+		t.Errorf("observed %d concurrently-held slots, want at most %d", peak, maxObserved)
+	}
+}
+
+// TestSemaphoreSetCapacityClampsAcquire verifies that shrinking capacity
+// after slots are already held is honored by subsequent Acquire calls.
+func TestSemaphoreSetCapacityClampsAcquire(t *testing.T) {
+	s := NewSemaphore(4, 4)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		if err := s.Acquire(ctx); err != nil {
+			t.Fatalf("Acquire %d: %v", i, err)
+		}
+	}
+
+	s.SetCapacity(1)
+	s.Release()
+	s.Release()
+	s.Release()
+
+	acquired := make(chan struct{})
+	go func() {
+		if err := s.Acquire(ctx); err != nil {
+			t.Errorf("Acquire: %v", err)
+		}
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Acquire succeeded while capacity 1 was already fully held")
+	default:
+	}
+
+	s.Release()
+	<-acquired
+}
@@ -0,0 +1,29 @@
+package codec
+
+import (
+	"fmt"
+
+	"github.com/hamba/avro/v2"
+)
+
+// AvroUnmarshaler decodes Avro-encoded Order payloads against a fixed
+// schema, for producers that prefer compact binary messages over JSON.
+type AvroUnmarshaler struct {
+	schema avro.Schema
+}
+
+func NewAvroUnmarshaler(schemaJSON string) (*AvroUnmarshaler, error) {
+	schema, err := avro.Parse(schemaJSON)
+	if err != nil {
+		return nil, fmt.Errorf("parse avro schema: %w", err)
+	}
+	return &AvroUnmarshaler{schema: schema}, nil
+}
+
+func (a *AvroUnmarshaler) Unmarshal(raw []byte, attrs map[string]string) (Order, Metadata, error) {
+	var ord Order
+	if err := avro.Unmarshal(a.schema, raw, &ord); err != nil {
+		return Order{}, Metadata{}, fmt.Errorf("unmarshal avro order: %w", err)
+	}
+	return ord, Metadata{}, nil
+}
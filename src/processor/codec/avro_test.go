@@ -0,0 +1,78 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/hamba/avro/v2"
+)
+
+const testOrderSchema = `{
+	"type": "record",
+	"name": "Order",
+	"fields": [
+		{"name": "order_id", "type": "string"},
+		{"name": "customer_id", "type": "int"},
+		{"name": "status", "type": "string"},
+		{"name": "items", "type": {"type": "array", "items": {
+			"type": "record",
+			"name": "Item",
+			"fields": [
+				{"name": "product_id", "type": "string"},
+				{"name": "quantity", "type": "int"},
+				{"name": "price", "type": "double"}
+			]
+		}}},
+		{"name": "created_at", "type": "long"}
+	]
+}`
+
+func TestNewAvroUnmarshalerInvalidSchema(t *testing.T) {
+	if _, err := NewAvroUnmarshaler("not a schema"); err == nil {
+		t.Fatal("expected an error for an invalid schema, got nil")
+	}
+}
+
+func TestAvroUnmarshalerUnmarshal(t *testing.T) {
+	c, err := NewAvroUnmarshaler(testOrderSchema)
+	if err != nil {
+		t.Fatalf("NewAvroUnmarshaler: %v", err)
+	}
+
+	want := Order{
+		OrderID:    "ord-1",
+		CustomerID: 9,
+		Status:     "created",
+		Items: []Item{
+			{ProductID: "sku-1", Quantity: 2, Price: 19.99},
+		},
+		CreatedAt: 1700000000000,
+	}
+
+	schema := avro.MustParse(testOrderSchema)
+	raw, err := avro.Marshal(schema, want)
+	if err != nil {
+		t.Fatalf("avro.Marshal: %v", err)
+	}
+
+	got, _, err := c.Unmarshal(raw, nil)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got.OrderID != want.OrderID || got.CustomerID != want.CustomerID || got.Status != want.Status || got.CreatedAt != want.CreatedAt {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+	}
+	if len(got.Items) != 1 || got.Items[0] != want.Items[0] {
+		t.Errorf("Unmarshal() Items = %+v, want %+v", got.Items, want.Items)
+	}
+}
+
+func TestAvroUnmarshalerUnmarshalInvalidData(t *testing.T) {
+	c, err := NewAvroUnmarshaler(testOrderSchema)
+	if err != nil {
+		t.Fatalf("NewAvroUnmarshaler: %v", err)
+	}
+
+	if _, _, err := c.Unmarshal([]byte("not avro"), nil); err == nil {
+		t.Fatal("expected an error for malformed avro data, got nil")
+	}
+}
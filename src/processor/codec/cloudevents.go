@@ -0,0 +1,116 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CloudEvents binary-mode SQS message attributes (see
+// https://github.com/cloudevents/spec, SQS binding section).
+const (
+	ceAttrID              = "ce-id"
+	ceAttrType            = "ce-type"
+	ceAttrSource          = "ce-source"
+	ceAttrTime            = "ce-time"
+	ceAttrDataContentType = "datacontenttype"
+)
+
+// EventHandlerFunc processes an Order extracted from a CloudEvent of a
+// specific type (e.g. "order.created").
+type EventHandlerFunc func(ctx context.Context, ord Order) error
+
+// EventRouter dispatches a decoded Order to the handler registered for
+// its CloudEvent type, falling back to a default handler when no
+// specific handler has been registered.
+type EventRouter struct {
+	mu       sync.RWMutex
+	handlers map[string]EventHandlerFunc
+	fallback EventHandlerFunc
+}
+
+func NewEventRouter() *EventRouter {
+	return &EventRouter{handlers: make(map[string]EventHandlerFunc)}
+}
+
+// Handle registers h to process every CloudEvent of the given type.
+func (r *EventRouter) Handle(eventType string, h EventHandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[eventType] = h
+}
+
+// Default registers the handler used for event types with no specific
+// registration.
+func (r *EventRouter) Default(h EventHandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = h
+}
+
+// Dispatch routes ord to the handler registered for eventType, or to the
+// default handler if none is registered. It returns an error if no
+// handler applies.
+func (r *EventRouter) Dispatch(ctx context.Context, eventType string, ord Order) error {
+	r.mu.RLock()
+	h, ok := r.handlers[eventType]
+	fallback := r.fallback
+	r.mu.RUnlock()
+
+	if !ok {
+		if fallback == nil {
+			return fmt.Errorf("no handler registered for event type %q", eventType)
+		}
+		h = fallback
+	}
+	return h(ctx, ord)
+}
+
+// CloudEventsUnmarshaler decodes SQS messages carrying CloudEvents v1.0
+// payloads in binary content mode: the event data is the SQS message
+// body and the envelope rides along as `ce-*` message attributes.
+type CloudEventsUnmarshaler struct {
+	// Router, if set, lets callers dispatch the decoded Order by event
+	// type via Dispatch. Unmarshal itself never invokes it.
+	Router *EventRouter
+}
+
+func NewCloudEventsUnmarshaler(router *EventRouter) *CloudEventsUnmarshaler {
+	return &CloudEventsUnmarshaler{Router: router}
+}
+
+func (c *CloudEventsUnmarshaler) Unmarshal(raw []byte, attrs map[string]string) (Order, Metadata, error) {
+	meta := Metadata{
+		EventID:     attrs[ceAttrID],
+		EventType:   attrs[ceAttrType],
+		Source:      attrs[ceAttrSource],
+		ContentType: attrs[ceAttrDataContentType],
+	}
+	if meta.EventType == "" {
+		return Order{}, meta, fmt.Errorf("missing %s attribute", ceAttrType)
+	}
+	if ts, ok := attrs[ceAttrTime]; ok {
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return Order{}, meta, fmt.Errorf("parse %s %q: %w", ceAttrTime, ts, err)
+		}
+		meta.Time = t
+	}
+
+	var ord Order
+	if err := json.Unmarshal(raw, &ord); err != nil {
+		return Order{}, meta, fmt.Errorf("unmarshal cloudevent data: %w", err)
+	}
+	return ord, meta, nil
+}
+
+// Dispatch routes ord to c.Router by meta.EventType. It is a no-op
+// returning nil if no router is configured.
+func (c *CloudEventsUnmarshaler) Dispatch(ctx context.Context, meta Metadata, ord Order) error {
+	if c.Router == nil {
+		return nil
+	}
+	return c.Router.Dispatch(ctx, meta.EventType, ord)
+}
@@ -0,0 +1,133 @@
+package codec
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCloudEventsUnmarshalerUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		attrs    map[string]string
+		wantMeta Metadata
+		wantErr  bool
+	}{
+		{
+			name: "decodes order data plus the ce-* envelope attributes",
+			body: `{"order_id":"ord-1","customer_id":3,"status":"created"}`,
+			attrs: map[string]string{
+				ceAttrID:              "evt-1",
+				ceAttrType:            "order.created",
+				ceAttrSource:          "checkout-service",
+				ceAttrTime:            "2024-01-02T03:04:05Z",
+				ceAttrDataContentType: "application/json",
+			},
+			wantMeta: Metadata{
+				EventID:     "evt-1",
+				EventType:   "order.created",
+				Source:      "checkout-service",
+				Time:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				ContentType: "application/json",
+			},
+		},
+		{
+			name:    "missing ce-type is rejected",
+			body:    `{"order_id":"ord-1"}`,
+			attrs:   map[string]string{ceAttrID: "evt-1"},
+			wantErr: true,
+		},
+		{
+			name: "malformed ce-time is rejected",
+			body: `{"order_id":"ord-1"}`,
+			attrs: map[string]string{
+				ceAttrType: "order.created",
+				ceAttrTime: "not-a-timestamp",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "malformed order data is rejected",
+			body:    `not json`,
+			attrs:   map[string]string{ceAttrType: "order.created"},
+			wantErr: true,
+		},
+	}
+
+	c := NewCloudEventsUnmarshaler(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ord, meta, err := c.Unmarshal([]byte(tt.body), tt.attrs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ord.OrderID != "ord-1" {
+				t.Errorf("OrderID = %q, want ord-1", ord.OrderID)
+			}
+			if meta != tt.wantMeta {
+				t.Errorf("Metadata = %+v, want %+v", meta, tt.wantMeta)
+			}
+		})
+	}
+}
+
+func TestEventRouterDispatch(t *testing.T) {
+	var calledWith string
+	r := NewEventRouter()
+	r.Handle("order.created", func(ctx context.Context, ord Order) error {
+		calledWith = "specific"
+		return nil
+	})
+	r.Default(func(ctx context.Context, ord Order) error {
+		calledWith = "default"
+		return nil
+	})
+
+	if err := r.Dispatch(context.Background(), "order.created", Order{}); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if calledWith != "specific" {
+		t.Errorf("calledWith = %q, want specific", calledWith)
+	}
+
+	if err := r.Dispatch(context.Background(), "order.cancelled", Order{}); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if calledWith != "default" {
+		t.Errorf("calledWith = %q, want default", calledWith)
+	}
+}
+
+func TestEventRouterDispatchNoHandlerNoDefault(t *testing.T) {
+	r := NewEventRouter()
+	err := r.Dispatch(context.Background(), "order.created", Order{})
+	if err == nil {
+		t.Fatal("expected an error when no handler or default is registered")
+	}
+}
+
+func TestCloudEventsUnmarshalerDispatch(t *testing.T) {
+	handlerErr := errors.New("boom")
+	r := NewEventRouter()
+	r.Handle("order.created", func(ctx context.Context, ord Order) error {
+		return handlerErr
+	})
+
+	c := NewCloudEventsUnmarshaler(r)
+	if err := c.Dispatch(context.Background(), Metadata{EventType: "order.created"}, Order{}); !errors.Is(err, handlerErr) {
+		t.Errorf("Dispatch() error = %v, want %v", err, handlerErr)
+	}
+
+	noRouter := NewCloudEventsUnmarshaler(nil)
+	if err := noRouter.Dispatch(context.Background(), Metadata{EventType: "order.created"}, Order{}); err != nil {
+		t.Errorf("Dispatch() with nil Router = %v, want nil", err)
+	}
+}
@@ -0,0 +1,40 @@
+// Package codec decodes inbound SQS message bodies into Orders. The
+// wire format varies by producer (SNS fan-out, direct SQS, CloudEvents,
+// Avro), so callers select an Unmarshaler implementation rather than
+// hard-coding a single decode path.
+package codec
+
+import "time"
+
+// Item mirrors a single line item on an order.
+type Item struct {
+	ProductID string  `json:"product_id" avro:"product_id"`
+	Quantity  int     `json:"quantity" avro:"quantity"`
+	Price     float64 `json:"price" avro:"price"`
+}
+
+// Order is the domain payload carried by every supported wire format.
+type Order struct {
+	OrderID    string `json:"order_id" avro:"order_id"`
+	CustomerID int    `json:"customer_id" avro:"customer_id"`
+	Status     string `json:"status" avro:"status"`
+	Items      []Item `json:"items" avro:"items"`
+	CreatedAt  int64  `json:"created_at" avro:"created_at"` // Unix timestamp in milliseconds
+}
+
+// Metadata carries envelope information that exists alongside the Order
+// in some wire formats (currently populated by the CloudEvents codec;
+// zero-valued for formats that have no envelope).
+type Metadata struct {
+	EventID     string
+	EventType   string
+	Source      string
+	Time        time.Time
+	ContentType string
+}
+
+// Unmarshaler decodes a raw SQS message body, plus its flattened message
+// attributes, into an Order and its Metadata.
+type Unmarshaler interface {
+	Unmarshal(raw []byte, attrs map[string]string) (Order, Metadata, error)
+}
@@ -0,0 +1,23 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RawJSONUnmarshaler decodes an Order directly from the SQS message
+// body, for producers that publish straight to SQS without an SNS
+// fan-out envelope.
+type RawJSONUnmarshaler struct{}
+
+func NewRawJSONUnmarshaler() *RawJSONUnmarshaler {
+	return &RawJSONUnmarshaler{}
+}
+
+func (RawJSONUnmarshaler) Unmarshal(raw []byte, attrs map[string]string) (Order, Metadata, error) {
+	var ord Order
+	if err := json.Unmarshal(raw, &ord); err != nil {
+		return Order{}, Metadata{}, fmt.Errorf("unmarshal order: %w", err)
+	}
+	return ord, Metadata{}, nil
+}
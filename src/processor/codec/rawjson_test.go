@@ -0,0 +1,45 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRawJSONUnmarshalerUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    Order
+		wantErr bool
+	}{
+		{
+			name: "decodes an order directly from the body",
+			body: `{"order_id":"ord-1","customer_id":7,"status":"created"}`,
+			want: Order{OrderID: "ord-1", CustomerID: 7, Status: "created"},
+		},
+		{
+			name:    "invalid JSON",
+			body:    `not json`,
+			wantErr: true,
+		},
+	}
+
+	c := NewRawJSONUnmarshaler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := c.Unmarshal([]byte(tt.body), nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
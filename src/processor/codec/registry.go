@@ -0,0 +1,33 @@
+package codec
+
+import "fmt"
+
+// Supported MESSAGE_CODEC values.
+const (
+	SNSJSON     = "sns-json"
+	RawJSON     = "raw-json"
+	CloudEvents = "cloudevents"
+	Avro        = "avro"
+)
+
+// New constructs the Unmarshaler selected by name (the MESSAGE_CODEC env
+// var). router is only consulted by the cloudevents codec; avroSchema is
+// only consulted by the avro codec. An empty name selects SNSJSON, the
+// processor's original wire format.
+func New(name string, router *EventRouter, avroSchema string) (Unmarshaler, error) {
+	switch name {
+	case "", SNSJSON:
+		return NewSNSUnmarshaler(), nil
+	case RawJSON:
+		return NewRawJSONUnmarshaler(), nil
+	case CloudEvents:
+		return NewCloudEventsUnmarshaler(router), nil
+	case Avro:
+		if avroSchema == "" {
+			return nil, fmt.Errorf("MESSAGE_CODEC=avro requires AVRO_SCHEMA to be set")
+		}
+		return NewAvroUnmarshaler(avroSchema)
+	default:
+		return nil, fmt.Errorf("unknown MESSAGE_CODEC %q", name)
+	}
+}
@@ -0,0 +1,53 @@
+package codec
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		codecName  string
+		avroSchema string
+		wantType   string // %T of the returned Unmarshaler
+		wantErr    bool
+	}{
+		{name: "empty name defaults to sns-json", codecName: "", wantType: "*codec.SNSUnmarshaler"},
+		{name: "sns-json", codecName: SNSJSON, wantType: "*codec.SNSUnmarshaler"},
+		{name: "raw-json", codecName: RawJSON, wantType: "*codec.RawJSONUnmarshaler"},
+		{name: "cloudevents", codecName: CloudEvents, wantType: "*codec.CloudEventsUnmarshaler"},
+		{name: "avro without a schema is rejected", codecName: Avro, wantErr: true},
+		{name: "unknown codec name is rejected", codecName: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.codecName, NewEventRouter(), tt.avroSchema)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotType := typeName(got); gotType != tt.wantType {
+				t.Errorf("New() returned %s, want %s", gotType, tt.wantType)
+			}
+		})
+	}
+}
+
+func typeName(u Unmarshaler) string {
+	switch u.(type) {
+	case *SNSUnmarshaler:
+		return "*codec.SNSUnmarshaler"
+	case *RawJSONUnmarshaler:
+		return "*codec.RawJSONUnmarshaler"
+	case *CloudEventsUnmarshaler:
+		return "*codec.CloudEventsUnmarshaler"
+	case *AvroUnmarshaler:
+		return "*codec.AvroUnmarshaler"
+	default:
+		return "unknown"
+	}
+}
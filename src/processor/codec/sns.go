@@ -0,0 +1,33 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SNSUnmarshaler decodes the SNS-fan-out envelope (the SQS message body
+// is an SNS notification whose `Message` field holds the JSON-encoded
+// Order). This is the processor's original, and still default, wire
+// format.
+type SNSUnmarshaler struct{}
+
+func NewSNSUnmarshaler() *SNSUnmarshaler {
+	return &SNSUnmarshaler{}
+}
+
+func (SNSUnmarshaler) Unmarshal(raw []byte, attrs map[string]string) (Order, Metadata, error) {
+	type snsEnvelope struct {
+		Message string `json:"Message"`
+	}
+
+	var env snsEnvelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return Order{}, Metadata{}, fmt.Errorf("unmarshal SNS envelope: %w", err)
+	}
+
+	var ord Order
+	if err := json.Unmarshal([]byte(env.Message), &ord); err != nil {
+		return Order{}, Metadata{}, fmt.Errorf("unmarshal order: %w", err)
+	}
+	return ord, Metadata{}, nil
+}
@@ -0,0 +1,50 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSNSUnmarshalerUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    Order
+		wantErr bool
+	}{
+		{
+			name: "unwraps the inner order from the SNS envelope",
+			body: `{"Message":"{\"order_id\":\"ord-1\",\"customer_id\":42,\"status\":\"created\"}"}`,
+			want: Order{OrderID: "ord-1", CustomerID: 42, Status: "created"},
+		},
+		{
+			name:    "invalid envelope JSON",
+			body:    `not json`,
+			wantErr: true,
+		},
+		{
+			name:    "envelope Message field isn't valid order JSON",
+			body:    `{"Message":"not json"}`,
+			wantErr: true,
+		},
+	}
+
+	c := NewSNSUnmarshaler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := c.Unmarshal([]byte(tt.body), nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package consumer
+
+import "time"
+
+// Supported Config.QueueType values.
+const (
+	QueueTypeStandard = "standard"
+	QueueTypeFIFO     = "fifo"
+)
+
+// Config tunes how a Consumer polls and processes a single SQS queue.
+type Config struct {
+	// QueueType selects the polling strategy: "standard" (default) uses
+	// a single global concurrency limit with no ordering guarantees;
+	// "fifo" serializes messages within each MessageGroupId while
+	// running different groups in parallel.
+	QueueType string
+	// Concurrency bounds how many messages are handled at once overall
+	// (across all groups, for QueueTypeFIFO). Ignored if Semaphore is set.
+	Concurrency int
+	// Semaphore overrides the default fixed-capacity semaphore, e.g. with
+	// an *autoscale.Semaphore whose capacity adapts to queue depth and
+	// latency. Concurrency is ignored when this is set.
+	Semaphore Semaphore
+	// OnReceiveError, if set, is called every time a ReceiveMessage call
+	// fails (other than because ctx was canceled for shutdown), so
+	// callers such as an autoscale.Controller can back off concurrency in
+	// response to real SQS receive failures.
+	OnReceiveError func()
+	// VisibilityTimeout is requested on every ReceiveMessage call and is
+	// also the interval the heartbeat goroutine renews while a handler
+	// is still running.
+	VisibilityTimeout int32
+	// WaitTimeSeconds is the long-poll duration passed to ReceiveMessage.
+	WaitTimeSeconds int32
+	// MaxMessages is the batch size requested per ReceiveMessage call.
+	MaxMessages int32
+	// VisibilityHeartbeatInterval controls how often the in-flight
+	// heartbeat renews a message's visibility timeout. Defaults to half
+	// of VisibilityTimeout, matching SQS best practice.
+	VisibilityHeartbeatInterval time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.QueueType == "" {
+		c.QueueType = QueueTypeStandard
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 1
+	}
+	if c.VisibilityTimeout <= 0 {
+		c.VisibilityTimeout = 60
+	}
+	if c.WaitTimeSeconds <= 0 {
+		c.WaitTimeSeconds = 20
+	}
+	if c.MaxMessages <= 0 {
+		c.MaxMessages = 10
+	}
+	if c.VisibilityHeartbeatInterval <= 0 {
+		c.VisibilityHeartbeatInterval = time.Duration(c.VisibilityTimeout) * time.Second / 2
+	}
+	return c
+}
@@ -0,0 +1,214 @@
+// Package consumer implements a reusable SQS polling loop: long-poll,
+// bounded concurrency, graceful drain on shutdown, panic-recovering
+// handlers, and a visibility heartbeat for long-running work. Business
+// logic lives entirely in the MessageHandler passed to New.
+package consumer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Consumer polls a single SQS queue and dispatches messages to a
+// MessageHandler with bounded concurrency.
+type Consumer struct {
+	client   *sqs.Client
+	queueURL string
+	handler  MessageHandler
+	cfg      Config
+
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	sem      Semaphore
+	inFlight int64
+}
+
+// New constructs a Consumer for queueURL. Options are applied in order,
+// so WithPanicRecovery (or similar handler-wrapping options) should
+// generally come last to wrap the full handler chain.
+func New(client *sqs.Client, queueURL string, handler MessageHandler, cfg Config, opts ...Option) *Consumer {
+	cfg = cfg.withDefaults()
+	sem := cfg.Semaphore
+	if sem == nil {
+		sem = newFixedSemaphore(cfg.Concurrency)
+	}
+
+	c := &Consumer{
+		client:   client,
+		queueURL: queueURL,
+		handler:  handler,
+		cfg:      cfg,
+		stop:     make(chan struct{}),
+		sem:      sem,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// StartListening begins polling in a background goroutine and registers
+// with wg so callers running multiple consumers can wait on one
+// WaitGroup for a clean shutdown. It returns immediately.
+func (c *Consumer) StartListening(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.run(ctx)
+	}()
+}
+
+// StopListening stops the consumer from receiving new messages. Messages
+// already in flight are still drained before the run loop returns; it is
+// safe to call StopListening more than once.
+func (c *Consumer) StopListening() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+// InFlight reports the approximate number of messages currently being
+// handled, for callers that want to surface queue depth in metrics.
+func (c *Consumer) InFlight() int {
+	return int(atomic.LoadInt64(&c.inFlight))
+}
+
+// Capacity reports the Consumer's current concurrency limit. For the
+// default fixed semaphore this is just Config.Concurrency; for a
+// resizable Semaphore (e.g. *autoscale.Semaphore) it reflects the
+// latest adjustment.
+func (c *Consumer) Capacity() int {
+	if cr, ok := c.sem.(capacityReporter); ok {
+		return int(cr.Capacity())
+	}
+	return c.cfg.Concurrency
+}
+
+func (c *Consumer) run(ctx context.Context) {
+	if c.cfg.QueueType == QueueTypeFIFO {
+		c.runFIFO(ctx)
+		return
+	}
+	c.runStandard(ctx)
+}
+
+// receive long-polls for the next batch of messages, backing off on
+// errors that aren't caused by shutdown.
+func (c *Consumer) receive(ctx context.Context) ([]types.Message, bool) {
+	out, err := c.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:              &c.queueURL,
+		MaxNumberOfMessages:   c.cfg.MaxMessages,
+		WaitTimeSeconds:       c.cfg.WaitTimeSeconds,
+		VisibilityTimeout:     c.cfg.VisibilityTimeout,
+		AttributeNames:        []types.QueueAttributeName{types.QueueAttributeNameAll}, // includes MessageGroupId/MessageDeduplicationId for FIFO queues
+		MessageAttributeNames: []string{"All"},
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false // shutting down; let the caller's select return
+		}
+		log.Printf("consumer: receive error: %v", err)
+		if c.cfg.OnReceiveError != nil {
+			c.cfg.OnReceiveError()
+		}
+		time.Sleep(2 * time.Second)
+		return nil, false
+	}
+	return out.Messages, true
+}
+
+// runStandard processes messages with a single global concurrency limit
+// and no ordering guarantees across messages.
+func (c *Consumer) runStandard(ctx context.Context) {
+	var inFlight sync.WaitGroup
+
+	for {
+		select {
+		case <-ctx.Done():
+			inFlight.Wait()
+			return
+		case <-c.stop:
+			inFlight.Wait()
+			return
+		default:
+		}
+
+		messages, ok := c.receive(ctx)
+		if !ok {
+			continue
+		}
+
+		for _, msg := range messages {
+			if err := c.sem.Acquire(ctx); err != nil {
+				continue // shutting down
+			}
+			inFlight.Add(1)
+			atomic.AddInt64(&c.inFlight, 1)
+
+			go func(m types.Message) {
+				defer func() {
+					c.sem.Release()
+					inFlight.Done()
+					atomic.AddInt64(&c.inFlight, -1)
+				}()
+				c.handle(ctx, m)
+			}(msg)
+		}
+	}
+}
+
+func (c *Consumer) handle(ctx context.Context, m types.Message) {
+	hbStop := make(chan struct{})
+	var hbWG sync.WaitGroup
+	hbWG.Add(1)
+	go func() {
+		defer hbWG.Done()
+		c.heartbeat(ctx, m.ReceiptHandle, hbStop)
+	}()
+	defer func() {
+		close(hbStop)
+		hbWG.Wait()
+	}()
+
+	err := c.handler(ctx, newIncomingMessage(m))
+	switch {
+	case err == nil:
+		if _, derr := c.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: &c.queueURL, ReceiptHandle: m.ReceiptHandle}); derr != nil {
+			log.Printf("consumer: failed to delete message: %v", derr)
+		}
+	case errors.Is(err, ErrAlreadyHandled):
+		// handler already decided this message's fate; nothing to do.
+	default:
+		log.Printf("consumer: handler error: %v", err)
+	}
+}
+
+// heartbeat periodically extends the message's visibility timeout so a
+// handler that outlives VisibilityTimeout doesn't get redelivered to
+// another worker while still running.
+func (c *Consumer) heartbeat(ctx context.Context, receiptHandle *string, stop <-chan struct{}) {
+	ticker := time.NewTicker(c.cfg.VisibilityHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := c.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          &c.queueURL,
+				ReceiptHandle:     receiptHandle,
+				VisibilityTimeout: c.cfg.VisibilityTimeout,
+			}); err != nil {
+				log.Printf("consumer: failed to extend visibility: %v", err)
+			}
+		}
+	}
+}
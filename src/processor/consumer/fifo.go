@@ -0,0 +1,124 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// groupWorker serializes delivery of every message sharing a
+// MessageGroupId. Only one goroutine ever drains a given group's
+// backlog, so ordering within the group is preserved even though
+// different groups are drained concurrently.
+type groupWorker struct {
+	mu      sync.Mutex
+	backlog []types.Message
+}
+
+// fifoDispatcher tracks the in-flight group workers for one runFIFO
+// invocation.
+type fifoDispatcher struct {
+	consumer *Consumer
+
+	mu     sync.Mutex
+	groups map[string]*groupWorker
+	wg     sync.WaitGroup
+}
+
+// runFIFO processes messages honoring SQS FIFO ordering: messages within
+// the same MessageGroupId are handled strictly serially, while different
+// groups are handled in parallel, bounded by the Consumer's semaphore.
+func (c *Consumer) runFIFO(ctx context.Context) {
+	d := &fifoDispatcher{
+		consumer: c,
+		groups:   make(map[string]*groupWorker),
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.wg.Wait()
+			return
+		case <-c.stop:
+			d.wg.Wait()
+			return
+		default:
+		}
+
+		messages, ok := c.receive(ctx)
+		if !ok {
+			continue
+		}
+
+		for _, msg := range messages {
+			groupID := msg.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]
+			d.enqueue(ctx, groupID, msg)
+		}
+	}
+}
+
+// enqueue appends m to groupID's backlog, spawning a drain goroutine for
+// the group if one isn't already running.
+func (d *fifoDispatcher) enqueue(ctx context.Context, groupID string, m types.Message) {
+	d.mu.Lock()
+	gw, exists := d.groups[groupID]
+	if !exists {
+		gw = &groupWorker{}
+		d.groups[groupID] = gw
+	}
+	gw.mu.Lock()
+	gw.backlog = append(gw.backlog, m)
+	gw.mu.Unlock()
+	d.mu.Unlock()
+
+	if exists {
+		return // a drain goroutine is already working through this group's backlog
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.drain(ctx, groupID, gw)
+	}()
+}
+
+// drain processes groupID's backlog strictly in order, one message at a
+// time, until the backlog is empty, then removes the group from the map
+// so memory doesn't grow unboundedly over the consumer's lifetime.
+func (d *fifoDispatcher) drain(ctx context.Context, groupID string, gw *groupWorker) {
+	for {
+		gw.mu.Lock()
+		if len(gw.backlog) == 0 {
+			gw.mu.Unlock()
+
+			// Re-check under d.mu+gw.mu together so a concurrent enqueue
+			// that observed this group as existing can't race with us
+			// deleting it out from under its append.
+			d.mu.Lock()
+			gw.mu.Lock()
+			if len(gw.backlog) == 0 {
+				delete(d.groups, groupID)
+				gw.mu.Unlock()
+				d.mu.Unlock()
+				return
+			}
+			gw.mu.Unlock()
+			d.mu.Unlock()
+			continue
+		}
+
+		m := gw.backlog[0]
+		gw.backlog = gw.backlog[1:]
+		gw.mu.Unlock()
+
+		if err := d.consumer.sem.Acquire(ctx); err != nil {
+			return // shutting down
+		}
+		atomic.AddInt64(&d.consumer.inFlight, 1)
+		d.consumer.handle(ctx, m)
+		atomic.AddInt64(&d.consumer.inFlight, -1)
+		d.consumer.sem.Release()
+	}
+}
@@ -0,0 +1,155 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// newTestFIFOConsumer builds a Consumer suitable for exercising
+// fifoDispatcher without a real SQS client: handler always returns
+// ErrAlreadyHandled so Consumer.handle never calls DeleteMessage, and the
+// heartbeat interval is set long enough that its ticker never fires
+// during the test, so c.client (left nil) is never dereferenced.
+func newTestFIFOConsumer(handler MessageHandler) *Consumer {
+	cfg := Config{
+		QueueType:         QueueTypeFIFO,
+		Concurrency:       4,
+		VisibilityTimeout: 60,
+		WaitTimeSeconds:   20,
+		MaxMessages:       10,
+	}.withDefaults()
+	cfg.VisibilityHeartbeatInterval = time.Hour
+
+	return &Consumer{
+		handler: handler,
+		cfg:     cfg,
+		stop:    make(chan struct{}),
+		sem:     newFixedSemaphore(cfg.Concurrency),
+	}
+}
+
+func testMessage(groupID, body string) types.Message {
+	return types.Message{
+		ReceiptHandle: aws.String(groupID + "-" + body),
+		Body:          aws.String(body),
+		Attributes: map[string]string{
+			string(types.MessageSystemAttributeNameMessageGroupId): groupID,
+		},
+	}
+}
+
+// TestFIFODispatcherPreservesPerGroupOrder enqueues several messages into
+// two groups out of order and verifies each group's handler invocations
+// happen strictly in FIFO order, even though the groups themselves run
+// concurrently.
+func TestFIFODispatcherPreservesPerGroupOrder(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string][]string{}
+
+	handler := func(ctx context.Context, msg IncomingMessage) error {
+		groupID := msg.Raw.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]
+		time.Sleep(time.Millisecond) // give a slower handler a chance to race ahead if ordering were broken
+		mu.Lock()
+		seen[groupID] = append(seen[groupID], *msg.Raw.Body)
+		mu.Unlock()
+		return ErrAlreadyHandled
+	}
+
+	c := newTestFIFOConsumer(handler)
+	d := &fifoDispatcher{consumer: c, groups: make(map[string]*groupWorker)}
+	ctx := context.Background()
+
+	const perGroup = 10
+	for i := 0; i < perGroup; i++ {
+		d.enqueue(ctx, "order-1", testMessage("order-1", fmt.Sprintf("%d", i)))
+		d.enqueue(ctx, "order-2", testMessage("order-2", fmt.Sprintf("%d", i)))
+	}
+	d.wg.Wait()
+
+	for _, groupID := range []string{"order-1", "order-2"} {
+		got := seen[groupID]
+		if len(got) != perGroup {
+			t.Fatalf("group %s: got %d messages, want %d", groupID, len(got), perGroup)
+		}
+		for i, body := range got {
+			if body != fmt.Sprintf("%d", i) {
+				t.Errorf("group %s: position %d = %q, want %q", groupID, i, body, fmt.Sprintf("%d", i))
+			}
+		}
+	}
+}
+
+// TestFIFODispatcherCleansUpDrainedGroups verifies that once a group's
+// backlog is fully drained, its groupWorker is removed from the
+// dispatcher's map and the consumer's in-flight counter returns to zero,
+// so neither grows unboundedly over the consumer's lifetime.
+func TestFIFODispatcherCleansUpDrainedGroups(t *testing.T) {
+	handler := func(ctx context.Context, msg IncomingMessage) error {
+		return ErrAlreadyHandled
+	}
+
+	c := newTestFIFOConsumer(handler)
+	d := &fifoDispatcher{consumer: c, groups: make(map[string]*groupWorker)}
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		d.enqueue(ctx, "order-1", testMessage("order-1", fmt.Sprintf("%d", i)))
+	}
+	d.wg.Wait()
+
+	d.mu.Lock()
+	remaining := len(d.groups)
+	d.mu.Unlock()
+	if remaining != 0 {
+		t.Errorf("groups map has %d entries after drain, want 0", remaining)
+	}
+
+	if inFlight := atomic.LoadInt64(&c.inFlight); inFlight != 0 {
+		t.Errorf("inFlight = %d after drain, want 0", inFlight)
+	}
+}
+
+// TestFIFODispatcherInFlightCountsActiveMessages verifies InFlight()
+// reflects the number of messages actually being handled right now, not
+// the number of groups with a non-empty backlog: a single group with a
+// long backlog should report 1 in-flight message at a time, matching the
+// count a busy group of N queued-but-not-yet-handled messages would give
+// in the standard (non-FIFO) path.
+func TestFIFODispatcherInFlightCountsActiveMessages(t *testing.T) {
+	inHandler := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := func(ctx context.Context, msg IncomingMessage) error {
+		inHandler <- struct{}{}
+		<-release
+		return ErrAlreadyHandled
+	}
+
+	c := newTestFIFOConsumer(handler)
+	d := &fifoDispatcher{consumer: c, groups: make(map[string]*groupWorker)}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		d.enqueue(ctx, "order-1", testMessage("order-1", fmt.Sprintf("%d", i)))
+	}
+
+	for i := 0; i < 3; i++ {
+		<-inHandler // wait until the i'th message is actively being handled
+		if got := atomic.LoadInt64(&c.inFlight); got != 1 {
+			t.Errorf("message %d: inFlight = %d while handling, want 1", i, got)
+		}
+		release <- struct{}{}
+	}
+
+	d.wg.Wait()
+	if inFlight := atomic.LoadInt64(&c.inFlight); inFlight != 0 {
+		t.Errorf("inFlight = %d after drain, want 0", inFlight)
+	}
+}
@@ -0,0 +1,40 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MessageHandler processes a single message. Returning nil acks the
+// message (the Consumer deletes it). Returning ErrAlreadyHandled tells
+// the Consumer the handler already decided the message's fate itself
+// (e.g. dead-lettered and deleted it, or extended its visibility for a
+// custom retry) and no further action should be taken. Any other error
+// is logged and otherwise left alone, so the message redelivers once its
+// visibility timeout lapses.
+type MessageHandler func(ctx context.Context, msg IncomingMessage) error
+
+// ErrAlreadyHandled signals that a MessageHandler has already taken
+// final action on a message; see MessageHandler.
+var ErrAlreadyHandled = errors.New("consumer: message already handled")
+
+// Option configures a Consumer at construction time.
+type Option func(*Consumer)
+
+// WithPanicRecovery wraps the handler so a panic is converted into an
+// error instead of crashing the process, matching the at-least-once
+// semantics the rest of the Consumer relies on.
+func WithPanicRecovery() Option {
+	return func(c *Consumer) {
+		inner := c.handler
+		c.handler = func(ctx context.Context, msg IncomingMessage) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("handler panicked: %v", r)
+				}
+			}()
+			return inner(ctx, msg)
+		}
+	}
+}
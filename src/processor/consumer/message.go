@@ -0,0 +1,51 @@
+package consumer
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// IncomingMessage wraps an SQS message with the pieces handlers actually
+// need, so they don't have to reach into the raw SDK type for common
+// fields.
+type IncomingMessage struct {
+	// Raw is the underlying SQS message, in case a handler needs access
+	// to fields IncomingMessage doesn't surface directly.
+	Raw types.Message
+	// Attributes is Raw.MessageAttributes flattened to string values.
+	Attributes map[string]string
+	// ReceiveCount is ApproximateReceiveCount, defaulting to 1 if the
+	// attribute wasn't requested or is missing.
+	ReceiveCount int
+}
+
+func flattenAttrs(attrs map[string]types.MessageAttributeValue) map[string]string {
+	out := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		if v.StringValue != nil {
+			out[k] = *v.StringValue
+		}
+	}
+	return out
+}
+
+func receiveCountOf(m types.Message) int {
+	v, ok := m.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)]
+	if !ok {
+		return 1
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+func newIncomingMessage(m types.Message) IncomingMessage {
+	return IncomingMessage{
+		Raw:          m,
+		Attributes:   flattenAttrs(m.MessageAttributes),
+		ReceiveCount: receiveCountOf(m),
+	}
+}
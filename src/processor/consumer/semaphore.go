@@ -0,0 +1,46 @@
+package consumer
+
+import "context"
+
+// Semaphore bounds how many messages a Consumer hands to its handler at
+// once. The default, used when Config.Semaphore is nil, is a
+// fixed-capacity semaphore sized by Config.Concurrency. Pass an
+// *autoscale.Semaphore (or anything else satisfying this interface) via
+// Config.Semaphore for a capacity that can be resized while the Consumer
+// is running.
+type Semaphore interface {
+	Acquire(ctx context.Context) error
+	Release()
+}
+
+// capacityReporter is implemented by Semaphores whose capacity can
+// change at runtime (e.g. *autoscale.Semaphore); Consumer.Capacity uses
+// it when available.
+type capacityReporter interface {
+	Capacity() int64
+}
+
+// fixedSemaphore is a simple counting semaphore with constant capacity.
+type fixedSemaphore struct {
+	slots chan struct{}
+}
+
+func newFixedSemaphore(capacity int) *fixedSemaphore {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &fixedSemaphore{slots: make(chan struct{}, capacity)}
+}
+
+func (s *fixedSemaphore) Acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *fixedSemaphore) Release() {
+	<-s.slots
+}
@@ -2,13 +2,12 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"strconv"
 	"sync"
-	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -16,24 +15,176 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/cs6650/final-mastery/processor/autoscale"
+	"github.com/cs6650/final-mastery/processor/codec"
+	"github.com/cs6650/final-mastery/processor/consumer"
 	"github.com/cs6650/final-mastery/processor/metrics"
 )
 
-type Item struct {
-	ProductID string  `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+// dlqConfig holds the tunables for dead-letter routing and the backoff
+// applied to messages that are retried in place.
+type dlqConfig struct {
+	queueURL       string // DEAD_LETTER_QUEUE_URL; DLQ disabled if empty
+	maxAttempts    int    // attempts (ApproximateReceiveCount) before moving to the DLQ
+	baseBackoff    time.Duration
+	maxBackoff     time.Duration
+	visibilityBase int32 // the VisibilityTimeout requested on ReceiveMessage, used as the retry floor
 }
 
-type Order struct {
-	OrderID    string `json:"order_id"`
-	CustomerID int    `json:"customer_id"`
-	Status     string `json:"status"`
-	Items      []Item `json:"items"`
-	CreatedAt  int64  `json:"created_at"` // Unix timestamp in milliseconds
+func loadDLQConfig(visibilityBase int32) dlqConfig {
+	maxAttempts := 5
+	if s := os.Getenv("DLQ_MAX_ATTEMPTS"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			maxAttempts = v
+		}
+	}
+
+	baseBackoff := 5 * time.Second
+	if s := os.Getenv("DLQ_BASE_BACKOFF_SECONDS"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			baseBackoff = time.Duration(v) * time.Second
+		}
+	}
+
+	maxBackoff := 5 * time.Minute
+	if s := os.Getenv("DLQ_MAX_BACKOFF_SECONDS"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			maxBackoff = time.Duration(v) * time.Second
+		}
+	}
+
+	return dlqConfig{
+		queueURL:       os.Getenv("DEAD_LETTER_QUEUE_URL"),
+		maxAttempts:    maxAttempts,
+		baseBackoff:    baseBackoff,
+		maxBackoff:     maxBackoff,
+		visibilityBase: visibilityBase,
+	}
+}
+
+func (c dlqConfig) enabled() bool {
+	return c.queueURL != ""
+}
+
+// backoffFor returns the exponential backoff visibility timeout for the
+// given attempt (1-indexed, as reported by ApproximateReceiveCount).
+func (c dlqConfig) backoffFor(attempt int) int32 {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := c.baseBackoff * time.Duration(1<<uint(attempt-1))
+	if d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+	if d < time.Duration(c.visibilityBase)*time.Second {
+		return c.visibilityBase
+	}
+	return int32(d.Seconds())
+}
+
+// firstSeenOf returns the best-effort time the message first entered the
+// queue, derived from the SentTimestamp system attribute (SQS does not
+// track per-attempt history, so this is a stable proxy across retries).
+func firstSeenOf(m types.Message) time.Time {
+	v, ok := m.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)]
+	if !ok {
+		return time.Now()
+	}
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.UnixMilli(ms)
+}
+
+// retryOrDeadLetter decides, based on the message's receive count,
+// whether a failed message should be retried in place (via
+// ChangeMessageVisibility with an exponential backoff) or moved to the
+// dead-letter queue. It always deletes the original message once it has
+// been dead-lettered.
+func retryOrDeadLetter(ctx context.Context, client *sqs.Client, queueURL, workerID string, cfg dlqConfig, msg consumer.IncomingMessage, failureReason string) {
+	attempt := msg.ReceiveCount
+
+	if !cfg.enabled() || attempt < cfg.maxAttempts {
+		backoff := cfg.backoffFor(attempt)
+		if _, err := client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          &queueURL,
+			ReceiptHandle:     msg.Raw.ReceiptHandle,
+			VisibilityTimeout: backoff,
+		}); err != nil {
+			log.Printf("failed to change visibility for retry: %v", err)
+		}
+		log.Printf("retrying message (attempt=%d backoff=%ds): %s", attempt, backoff, failureReason)
+		return
+	}
+
+	if err := sendToDLQ(ctx, client, cfg.queueURL, workerID, msg, failureReason); err != nil {
+		log.Printf("failed to send message to DLQ, leaving for redelivery: %v", err)
+		return
+	}
+
+	if _, derr := client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: &queueURL, ReceiptHandle: msg.Raw.ReceiptHandle}); derr != nil {
+		log.Printf("failed to delete dead-lettered message: %v", derr)
+	}
 }
 
-var queueDepth int64 // Track approximate queue depth
+// sendToDLQ publishes the original message body to the dead-letter queue,
+// annotated with failure metadata as message attributes.
+func sendToDLQ(ctx context.Context, client *sqs.Client, dlqURL, workerID string, msg consumer.IncomingMessage, failureReason string) error {
+	firstSeen := firstSeenOf(msg.Raw)
+	_, err := client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    &dlqURL,
+		MessageBody: msg.Raw.Body,
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"FailureReason": {DataType: aws.String("String"), StringValue: aws.String(failureReason)},
+			"AttemptCount":  {DataType: aws.String("Number"), StringValue: aws.String(strconv.Itoa(msg.ReceiveCount))},
+			"FirstSeenAt":   {DataType: aws.String("String"), StringValue: aws.String(firstSeen.UTC().Format(time.RFC3339))},
+			"WorkerID":      {DataType: aws.String("String"), StringValue: aws.String(workerID)},
+		},
+	})
+	return err
+}
+
+// newOrderHandler builds the consumer.MessageHandler that decodes an
+// order via msgCodec, dispatches it through eventRouter, and routes
+// processing failures to the DLQ/retry path. This is the only
+// order-processing-specific piece of main; everything else is generic
+// polling/concurrency/shutdown plumbing owned by the consumer package.
+func newOrderHandler(client *sqs.Client, queueURL string, dlqCfg dlqConfig, workerID string, msgCodec codec.Unmarshaler, eventRouter *codec.EventRouter) consumer.MessageHandler {
+	ceCodec, isCloudEvents := msgCodec.(*codec.CloudEventsUnmarshaler)
+
+	return func(ctx context.Context, msg consumer.IncomingMessage) error {
+		ord, meta, err := msgCodec.Unmarshal([]byte(*msg.Raw.Body), msg.Attributes)
+		if err != nil {
+			log.Printf("failed to decode message: %v; body=%s", err, *msg.Raw.Body)
+			// malformed payloads can never be fixed by retrying; dead-letter
+			// immediately if a DLQ is configured, otherwise just drop them
+			// rather than leave them to be redelivered and fail forever.
+			if dlqCfg.enabled() {
+				if sendErr := sendToDLQ(ctx, client, dlqCfg.queueURL, workerID, msg, fmt.Sprintf("decode error: %v", err)); sendErr != nil {
+					return fmt.Errorf("decode error, and failed to send to DLQ: %w", sendErr)
+				}
+			}
+			if _, derr := client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: &queueURL, ReceiptHandle: msg.Raw.ReceiptHandle}); derr != nil {
+				log.Printf("failed to delete malformed message: %v", derr)
+			}
+			return consumer.ErrAlreadyHandled
+		}
+
+		var procErr error
+		if isCloudEvents {
+			procErr = ceCodec.Dispatch(ctx, meta, ord)
+		} else {
+			procErr = eventRouter.Dispatch(ctx, "order.created", ord)
+		}
+		if procErr != nil {
+			log.Printf("failed to process order %s: %v", ord.OrderID, procErr)
+			retryOrDeadLetter(ctx, client, queueURL, workerID, dlqCfg, msg, procErr.Error())
+			return consumer.ErrAlreadyHandled
+		}
+		return nil
+	}
+}
 
 func main() {
 	queueURL := os.Getenv("SQS_QUEUE_URL")
@@ -53,6 +204,26 @@ func main() {
 		}
 	}
 
+	// MAX_CONCURRENCY opts into adaptive concurrency: PROCESSOR_CONCURRENCY
+	// (or 1) becomes the starting point and MIN_CONCURRENCY/MAX_CONCURRENCY
+	// become the AIMD controller's floor/ceiling, replacing the fixed
+	// semaphore with one the processor can resize in response to queue
+	// depth and latency.
+	adaptiveConcurrency := false
+	minConcurrency := 1
+	if s := os.Getenv("MIN_CONCURRENCY"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			minConcurrency = v
+		}
+	}
+	maxConcurrency := concurrency
+	if s := os.Getenv("MAX_CONCURRENCY"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			maxConcurrency = v
+			adaptiveConcurrency = true
+		}
+	}
+
 	paymentSimSeconds := 3
 	if s := os.Getenv("PAYMENTSIM_SECONDS"); s != "" {
 		if v, err := strconv.Atoi(s); err == nil && v > 0 {
@@ -60,6 +231,16 @@ func main() {
 		}
 	}
 
+	const visibilityTimeout int32 = 60
+	dlqCfg := loadDLQConfig(visibilityTimeout)
+
+	workerID, err := os.Hostname()
+	if err != nil || workerID == "" {
+		workerID = fmt.Sprintf("worker-%d", os.Getpid())
+	} else {
+		workerID = fmt.Sprintf("%s-%d", workerID, os.Getpid())
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -71,19 +252,9 @@ func main() {
 		}
 	}()
 
-	// handle shutdown
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigs
-		log.Println("shutdown signal received")
-		cancel()
-	}()
-
 	// Load AWS config with optional custom endpoint
 	awsEndpoint := os.Getenv("AWS_ENDPOINT")
 	var cfg aws.Config
-	var err error
 	if awsEndpoint != "" {
 		resolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
 			return aws.Endpoint{URL: awsEndpoint, SigningRegion: os.Getenv("AWS_REGION")}, nil
@@ -98,109 +269,95 @@ func main() {
 
 	client := sqs.NewFromConfig(cfg)
 
-	// concurrency semaphore and waitgroup to wait for in-flight messages on shutdown
-	sem := make(chan struct{}, concurrency)
-	var wg sync.WaitGroup
-
-	log.Printf("starting processor: queue=%s concurrency=%d paymentsim=%ds", queueURL, concurrency, paymentSimSeconds)
-
-	// Poll loop
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("context cancelled, waiting for in-flight messages")
-			wg.Wait()
-			log.Println("processor shutdown complete")
-			return
-		default:
+	// The event router backs the cloudevents codec: "order.created" runs
+	// the normal payment-processing path, "order.cancelled" skips it, and
+	// anything else falls back to normal processing.
+	var cons *consumer.Consumer
+	var scaleController *autoscale.Controller
+	eventRouter := codec.NewEventRouter()
+	eventRouter.Handle("order.cancelled", func(ctx context.Context, ord codec.Order) error {
+		log.Printf("order %s cancelled, skipping payment processing", ord.OrderID)
+		return nil
+	})
+	processOrder := func(ctx context.Context, ord codec.Order) error {
+		fetchTime := time.Now()
+		currentDepth := 0
+		if cons != nil {
+			currentDepth = cons.InFlight()
 		}
 
-		// Receive messages (long polling)
-		out, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
-			QueueUrl:            &queueURL,
-			MaxNumberOfMessages: 10,
-			WaitTimeSeconds:     20,
-			VisibilityTimeout:   60,
-		})
-		if err != nil {
-			// Log and backoff
-			log.Printf("receive error: %v", err)
-			time.Sleep(2 * time.Second)
-			continue
-		}
+		queueLatency := fetchTime.UnixMilli() - ord.CreatedAt
+		metricsCollector.Record(ord.OrderID, "fetched", float64(queueLatency), currentDepth)
+		log.Printf("processing order %s (customer=%d) queue_latency=%dms", ord.OrderID, ord.CustomerID, queueLatency)
 
-		if len(out.Messages) == 0 {
-			// no messages, continue
-			continue
+		processStart := time.Now()
+		time.Sleep(time.Duration(paymentSimSeconds) * time.Second) // simulate payment verification / processing
+		processLatency := time.Since(processStart).Milliseconds()
+		metricsCollector.Record(ord.OrderID, "processed", float64(processLatency), currentDepth)
+		if scaleController != nil {
+			scaleController.RecordLatency(time.Duration(processLatency) * time.Millisecond)
 		}
 
-		for _, msg := range out.Messages {
-			// acquire semaphore
-			sem <- struct{}{}
-			wg.Add(1)
-			atomic.AddInt64(&queueDepth, 1)
-
-			go func(m types.Message) {
-				defer func() {
-					<-sem
-					wg.Done()
-					atomic.AddInt64(&queueDepth, -1)
-				}()
-
-				fetchTime := time.Now()
-
-				// process message (expect SNS envelope with Message field)
-				type SNSMessage struct {
-					Message string `json:"Message"`
-				}
-
-				var snsMsg SNSMessage
-				if err := json.Unmarshal([]byte(*m.Body), &snsMsg); err != nil {
-					log.Printf("failed to unmarshal SNS wrapper: %v; body=%s", err, *m.Body)
-					// delete bad message to avoid poison messages; consider DLQ in production
-					if _, derr := client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: &queueURL, ReceiptHandle: m.ReceiptHandle}); derr != nil {
-						log.Printf("failed to delete malformed message: %v", derr)
-					}
-					return
-				}
-
-				var ord Order
-				if err := json.Unmarshal([]byte(snsMsg.Message), &ord); err != nil {
-					log.Printf("failed to unmarshal order: %v; message=%s", err, snsMsg.Message)
-					if _, derr := client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: &queueURL, ReceiptHandle: m.ReceiptHandle}); derr != nil {
-						log.Printf("failed to delete malformed message: %v", derr)
-					}
-					return
-				}
+		totalLatency := time.Since(fetchTime).Milliseconds()
+		endToEndLatency := time.Now().UnixMilli() - ord.CreatedAt
+		log.Printf("completed order %s - process_latency=%dms total_latency=%dms end_to_end=%dms",
+			ord.OrderID, processLatency, totalLatency, endToEndLatency)
+		metricsCollector.Record(ord.OrderID, "completed", float64(endToEndLatency), currentDepth)
+		return nil
+	}
+	eventRouter.Handle("order.created", processOrder)
+	eventRouter.Default(processOrder)
 
-				// Record fetched metric
-				queueLatency := fetchTime.UnixMilli() - ord.CreatedAt
-				currentDepth := int(atomic.LoadInt64(&queueDepth))
-				metricsCollector.Record(ord.OrderID, "fetched", float64(queueLatency), currentDepth)
+	msgCodec, err := codec.New(os.Getenv("MESSAGE_CODEC"), eventRouter, os.Getenv("AVRO_SCHEMA"))
+	if err != nil {
+		log.Fatalf("failed to initialize message codec: %v", err)
+	}
 
-				log.Printf("processing order %s (customer=%d) queue_latency=%dms", ord.OrderID, ord.CustomerID, queueLatency)
+	queueType := os.Getenv("QUEUE_TYPE")
+	if queueType == "" {
+		queueType = consumer.QueueTypeStandard
+	}
 
-				processStart := time.Now()
-				// Simulate payment verification / processing
-				time.Sleep(time.Duration(paymentSimSeconds) * time.Second)
-				processLatency := time.Since(processStart).Milliseconds()
+	consCfg := consumer.Config{
+		QueueType:         queueType,
+		Concurrency:       concurrency,
+		VisibilityTimeout: visibilityTimeout,
+		WaitTimeSeconds:   20,
+		MaxMessages:       10,
+	}
+	if adaptiveConcurrency {
+		asem := autoscale.NewSemaphore(int64(concurrency), int64(maxConcurrency))
+		scaleController = autoscale.NewController(client, queueURL, asem, autoscale.Config{
+			MinConcurrency: int64(minConcurrency),
+			MaxConcurrency: int64(maxConcurrency),
+			OnSample: func(depth, notVisible int64, avgLatency time.Duration, capacity int64) {
+				log.Printf("autoscale: depth=%d not_visible=%d avg_latency=%s capacity=%d", depth, notVisible, avgLatency, capacity)
+				metricsCollector.Record("autoscale", "capacity", float64(capacity), int(depth))
+			},
+		})
+		consCfg.Semaphore = asem
+		consCfg.OnReceiveError = scaleController.RecordReceiveError
+	}
 
-				// Record processed metric
-				metricsCollector.Record(ord.OrderID, "processed", float64(processLatency), currentDepth)
+	handler := newOrderHandler(client, queueURL, dlqCfg, workerID, msgCodec, eventRouter)
+	cons = consumer.New(client, queueURL, handler, consCfg, consumer.WithPanicRecovery())
 
-				totalLatency := time.Since(fetchTime).Milliseconds()
-				endToEndLatency := time.Now().UnixMilli() - ord.CreatedAt
-				log.Printf("completed order %s - process_latency=%dms total_latency=%dms end_to_end=%dms",
-					ord.OrderID, processLatency, totalLatency, endToEndLatency)
+	var wg sync.WaitGroup
+	cons.StartListening(ctx, &wg)
+	if scaleController != nil {
+		go scaleController.Run(ctx)
+	}
 
-				// Record completed metric
-				metricsCollector.Record(ord.OrderID, "completed", float64(endToEndLatency), currentDepth)
+	log.Printf("starting processor: queue=%s queue_type=%s concurrency=%d adaptive=%t min_concurrency=%d max_concurrency=%d paymentsim=%ds codec=%s dlq=%s dlq_max_attempts=%d",
+		queueURL, queueType, concurrency, adaptiveConcurrency, minConcurrency, maxConcurrency, paymentSimSeconds, os.Getenv("MESSAGE_CODEC"), dlqCfg.queueURL, dlqCfg.maxAttempts)
 
-				// delete message after success
-				if _, derr := client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: &queueURL, ReceiptHandle: m.ReceiptHandle}); derr != nil {
-					log.Printf("failed to delete message: %v", derr)
-				}
-			}(msg)
-		}
-	}
+	// handle shutdown
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	<-sigs
+	log.Println("shutdown signal received, waiting for in-flight messages")
+	cons.StopListening()
+	wg.Wait()
+	cancel()
+	log.Println("processor shutdown complete")
 }
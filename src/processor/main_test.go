@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDLQConfigBackoffFor(t *testing.T) {
+	cfg := dlqConfig{
+		baseBackoff:    5 * time.Second,
+		maxBackoff:     5 * time.Minute,
+		visibilityBase: 60,
+	}
+
+	tests := []struct {
+		name    string
+		attempt int
+		want    int32
+	}{
+		{name: "first attempt floors at visibilityBase", attempt: 1, want: 60},
+		{name: "zero attempt treated as first attempt", attempt: 0, want: 60},
+		{name: "negative attempt treated as first attempt", attempt: -3, want: 60},
+		{name: "fourth attempt still under visibilityBase floor", attempt: 4, want: 60},
+		{name: "fifth attempt exceeds the floor", attempt: 5, want: 80},
+		{name: "backoff is clamped to maxBackoff", attempt: 10, want: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.backoffFor(tt.attempt); got != tt.want {
+				t.Errorf("backoffFor(%d) = %d, want %d", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
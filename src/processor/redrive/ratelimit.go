@@ -0,0 +1,36 @@
+package redrive
+
+import (
+	"context"
+	"time"
+)
+
+// rateLimiter paces redrive throughput to at most N messages/sec. A
+// zero rate disables pacing entirely.
+type rateLimiter struct {
+	interval time.Duration
+	last     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until it is time to send the next message, or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) {
+	if l.interval == 0 {
+		return
+	}
+	if !l.last.IsZero() {
+		if remaining := l.interval - time.Since(l.last); remaining > 0 {
+			select {
+			case <-time.After(remaining):
+			case <-ctx.Done():
+			}
+		}
+	}
+	l.last = time.Now()
+}
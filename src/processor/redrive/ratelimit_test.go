@@ -0,0 +1,41 @@
+package redrive
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiterDisabledForNonPositiveRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		l := newRateLimiter(rate)
+		if l.interval != 0 {
+			t.Errorf("newRateLimiter(%v).interval = %v, want 0", rate, l.interval)
+		}
+	}
+}
+
+func TestRateLimiterWaitPaces(t *testing.T) {
+	l := newRateLimiter(100) // 10ms between sends
+	ctx := context.Background()
+
+	l.wait(ctx) // first call never blocks
+
+	start := time.Now()
+	l.wait(ctx)
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Errorf("second wait() returned after %s, want it to pace to roughly the configured interval", elapsed)
+	}
+}
+
+func TestRateLimiterWaitDisabledDoesNotBlock(t *testing.T) {
+	l := newRateLimiter(0)
+	ctx := context.Background()
+
+	start := time.Now()
+	l.wait(ctx)
+	l.wait(ctx)
+	if elapsed := time.Since(start); elapsed > time.Millisecond {
+		t.Errorf("disabled rate limiter blocked for %s, want effectively instant", elapsed)
+	}
+}
@@ -0,0 +1,191 @@
+// Package redrive replays messages from a dead-letter queue back to
+// their source queue (or another destination), so operators can recover
+// from processor outages without reaching for the AWS console.
+package redrive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/itchyny/gojq"
+)
+
+// failureAttributeNames are the message attributes the processor's DLQ
+// subsystem adds when it dead-letters a message; they're stripped before
+// redriving so the message looks like a fresh delivery to its consumer.
+var failureAttributeNames = []string{"FailureReason", "AttemptCount", "FirstSeenAt", "WorkerID"}
+
+// Options configures a Replayer run.
+type Options struct {
+	// MaxMessages caps how many DLQ messages are inspected (0 = no cap,
+	// i.e. "move all").
+	MaxMessages int
+	// Sample, if >0, stops the run once this many messages have been
+	// replayed, even if more match the filter ("sample N" mode).
+	Sample int
+	// RatePerSec caps redrive throughput (0 = unlimited).
+	RatePerSec float64
+	// Filter is a jq-style expression evaluated against each message's
+	// JSON body; only messages for which it evaluates truthy are
+	// redriven. An empty filter matches everything.
+	Filter string
+	// DryRun reports what would be redriven without sending or deleting
+	// anything.
+	DryRun bool
+}
+
+// Result summarizes one Replayer.Run invocation.
+type Result struct {
+	Inspected int
+	Matched   int
+	Replayed  int
+	Skipped   int
+	Errors    int
+}
+
+// Replayer moves messages from a DLQ back to a target SQS queue.
+// Messages are sent with SQS SendMessage only; publishing to an SNS
+// topic is not supported.
+type Replayer struct {
+	client    *sqs.Client
+	dlqURL    string
+	targetURL string
+	opts      Options
+	filter    *gojq.Code
+}
+
+// New constructs a Replayer. An invalid Options.Filter expression is
+// reported here rather than at Run time.
+func New(client *sqs.Client, dlqURL, targetURL string, opts Options) (*Replayer, error) {
+	var code *gojq.Code
+	if opts.Filter != "" {
+		query, err := gojq.Parse(opts.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("parse filter expression: %w", err)
+		}
+		code, err = gojq.Compile(query)
+		if err != nil {
+			return nil, fmt.Errorf("compile filter expression: %w", err)
+		}
+	}
+	return &Replayer{client: client, dlqURL: dlqURL, targetURL: targetURL, opts: opts, filter: code}, nil
+}
+
+// Run drains the DLQ (bounded by Options.MaxMessages/Sample), redriving
+// every message that matches Options.Filter to the target.
+func (r *Replayer) Run(ctx context.Context) (Result, error) {
+	var res Result
+	limiter := newRateLimiter(r.opts.RatePerSec)
+
+	for {
+		if r.opts.MaxMessages > 0 && res.Inspected >= r.opts.MaxMessages {
+			return res, nil
+		}
+		if r.opts.Sample > 0 && res.Replayed >= r.opts.Sample {
+			return res, nil
+		}
+
+		out, err := r.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              &r.dlqURL,
+			MaxNumberOfMessages:   10,
+			WaitTimeSeconds:       2,
+			VisibilityTimeout:     30,
+			MessageAttributeNames: []string{"All"},
+		})
+		if err != nil {
+			return res, fmt.Errorf("receive from DLQ: %w", err)
+		}
+		if len(out.Messages) == 0 {
+			return res, nil
+		}
+
+		for _, msg := range out.Messages {
+			if r.opts.MaxMessages > 0 && res.Inspected >= r.opts.MaxMessages {
+				return res, nil
+			}
+			res.Inspected++
+
+			matched, err := r.matches(msg)
+			if err != nil {
+				res.Errors++
+				continue
+			}
+			if !matched {
+				res.Skipped++
+				continue
+			}
+			res.Matched++
+
+			if r.opts.Sample > 0 && res.Replayed >= r.opts.Sample {
+				continue
+			}
+
+			limiter.wait(ctx)
+
+			if r.opts.DryRun {
+				res.Replayed++
+				continue
+			}
+
+			if err := r.replay(ctx, msg); err != nil {
+				res.Errors++
+				continue
+			}
+			res.Replayed++
+		}
+	}
+}
+
+func (r *Replayer) matches(msg types.Message) (bool, error) {
+	if r.filter == nil {
+		return true, nil
+	}
+
+	var body interface{}
+	if err := json.Unmarshal([]byte(*msg.Body), &body); err != nil {
+		return false, fmt.Errorf("decode message body as JSON for filter: %w", err)
+	}
+
+	iter := r.filter.Run(body)
+	v, ok := iter.Next()
+	if !ok {
+		return false, nil
+	}
+	if err, isErr := v.(error); isErr {
+		return false, err
+	}
+	truthy := v != nil && v != false // jq truthiness: everything but false and null
+	return truthy, nil
+}
+
+// replay republishes msg to the target, preserving its original message
+// attributes minus the DLQ subsystem's failure metadata, then deletes it
+// from the DLQ.
+func (r *Replayer) replay(ctx context.Context, msg types.Message) error {
+	attrs := make(map[string]types.MessageAttributeValue, len(msg.MessageAttributes))
+outer:
+	for k, v := range msg.MessageAttributes {
+		for _, stripped := range failureAttributeNames {
+			if k == stripped {
+				continue outer
+			}
+		}
+		attrs[k] = v
+	}
+
+	if _, err := r.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          &r.targetURL,
+		MessageBody:       msg.Body,
+		MessageAttributes: attrs,
+	}); err != nil {
+		return fmt.Errorf("send to target: %w", err)
+	}
+
+	if _, err := r.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: &r.dlqURL, ReceiptHandle: msg.ReceiptHandle}); err != nil {
+		return fmt.Errorf("delete from DLQ: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package redrive
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+func TestReplayerMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		body    string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "empty filter matches everything",
+			body: `{"order_id":"ord-1"}`,
+			want: true,
+		},
+		{
+			name:   "filter selecting on a field value",
+			filter: `.order_id == "ord-1"`,
+			body:   `{"order_id":"ord-1"}`,
+			want:   true,
+		},
+		{
+			name:   "filter rejecting on a field value",
+			filter: `.order_id == "ord-1"`,
+			body:   `{"order_id":"ord-2"}`,
+			want:   false,
+		},
+		{
+			name:    "body isn't valid JSON",
+			filter:  `.order_id == "ord-1"`,
+			body:    `not json`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := New(nil, "dlq-url", "target-url", Options{Filter: tt.filter})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			got, err := r.matches(types.Message{Body: aws.String(tt.body)})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidFilterExpression(t *testing.T) {
+	if _, err := New(nil, "dlq-url", "target-url", Options{Filter: "("}); err == nil {
+		t.Fatal("expected an error for an unparseable filter expression, got nil")
+	}
+}
@@ -0,0 +1,88 @@
+package redrive
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// StatsResult groups DLQ messages by the FailureReason attribute the
+// processor's DLQ subsystem sets when it dead-letters a message.
+type StatsResult struct {
+	Total    int
+	ByReason map[string]int
+}
+
+// maxEmptyPolls bounds how many consecutive polls Stats will tolerate
+// that return zero newly-seen messages before concluding the DLQ has
+// been fully sampled. Since peeked messages can be re-received on the
+// very next poll (visibility is released immediately), "no messages in
+// this batch" alone can't be trusted as the stopping condition.
+const maxEmptyPolls = 3
+
+// Stats inspects up to sampleSize messages in the DLQ (0 = inspect until
+// no new messages are seen for a few consecutive polls) and groups them
+// by failure reason, without consuming them: each message's visibility
+// is released immediately after it's counted.
+func Stats(ctx context.Context, client *sqs.Client, dlqURL string, sampleSize int) (StatsResult, error) {
+	res := StatsResult{ByReason: make(map[string]int)}
+	seen := make(map[string]bool) // MessageId -> counted, since peeking can re-receive a message across polls
+	emptyPolls := 0
+
+	for (sampleSize <= 0 || res.Total < sampleSize) && emptyPolls < maxEmptyPolls {
+		out, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              &dlqURL,
+			MaxNumberOfMessages:   10,
+			WaitTimeSeconds:       2,
+			VisibilityTimeout:     5,
+			MessageAttributeNames: []string{"All"},
+		})
+		if err != nil {
+			return res, fmt.Errorf("receive from DLQ: %w", err)
+		}
+		if len(out.Messages) == 0 {
+			emptyPolls++
+			continue
+		}
+
+		sawNew := false
+		for _, msg := range out.Messages {
+			if sampleSize > 0 && res.Total >= sampleSize {
+				break
+			}
+			if msg.MessageId != nil {
+				if seen[*msg.MessageId] {
+					continue
+				}
+				seen[*msg.MessageId] = true
+			}
+			sawNew = true
+
+			reason := "unknown"
+			if v, ok := msg.MessageAttributes["FailureReason"]; ok && v.StringValue != nil {
+				reason = *v.StringValue
+			}
+			res.ByReason[reason]++
+			res.Total++
+
+			// Release visibility immediately so this peek doesn't delay
+			// a real redrive or another operator's inspection.
+			if _, err := client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          &dlqURL,
+				ReceiptHandle:     msg.ReceiptHandle,
+				VisibilityTimeout: 0,
+			}); err != nil {
+				log.Printf("redrive: failed to release stats peek visibility: %v", err)
+			}
+		}
+
+		if sawNew {
+			emptyPolls = 0
+		} else {
+			emptyPolls++
+		}
+	}
+	return res, nil
+}